@@ -0,0 +1,64 @@
+package golog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultFlushInterval matches glog's own default background flush cadence.
+const defaultFlushInterval = 30 * time.Second
+
+// SetFlushInterval overrides how often this Logger's background daemon
+// drains its sinks. It takes effect on the daemon's next cycle.
+func (self *Logger) SetFlushInterval(d time.Duration) {
+	self.flushInterval.Store(int64(d))
+}
+
+// ensureFlushDaemon lazily starts the background goroutine that periodically
+// flushes this Logger's sinks. It's started the first time a sink is added -
+// a Logger with no sinks has nothing worth flushing on a timer.
+func (self *Logger) ensureFlushDaemon() {
+	self.flushDaemonOnce.Do(func() {
+		go self.flushDaemonLoop()
+	})
+}
+
+func (self *Logger) flushDaemonLoop() {
+	for {
+		interval := time.Duration(self.flushInterval.Load())
+		if interval <= 0 {
+			interval = defaultFlushInterval
+		}
+		time.Sleep(interval)
+		self.flushSinks()
+	}
+}
+
+var signalFlushOnce sync.Once
+
+// EnableSignalFlush installs a SIGINT/SIGTERM handler that calls Flush()
+// before letting the signal take its default action. It's opt-in - most
+// programs already have their own shutdown sequence, and installing this
+// unconditionally would fight with it. Safe to call more than once; only
+// the first call installs the handler.
+func EnableSignalFlush() {
+	signalFlushOnce.Do(func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+		go func() {
+			sig := <-sigCh
+			Flush()
+
+			// Stop intercepting the signal and re-raise it so the process
+			// terminates the way it would have without this handler.
+			signal.Stop(sigCh)
+			if p, err := os.FindProcess(os.Getpid()); err == nil {
+				p.Signal(sig)
+			}
+		}()
+	})
+}
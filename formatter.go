@@ -0,0 +1,189 @@
+package golog
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// atomicFlag stores an int flag bitmask atomically. TextFormatter.Flag is
+// swapped concurrently with Format() reading it, same contention concern
+// Logger.flag addressed.
+type atomicFlag struct {
+	v atomic.Int64
+}
+
+func (a *atomicFlag) Store(flag int) {
+	a.v.Store(int64(flag))
+}
+
+func (a *atomicFlag) Load() int {
+	return int(a.v.Load())
+}
+
+// Entry is the structured representation of a single log line. Logger.Log
+// builds one of these for every call and hands it to the active Formatter,
+// which is responsible for turning it into the bytes that actually get
+// written.
+type Entry struct {
+	Level   Level
+	Time    time.Time
+	Name    string
+	File    string
+	Line    int
+	Message string
+	Fields  map[string]interface{}
+	Color   Color
+}
+
+// Formatter turns an Entry into bytes appended to buf. Implementations must
+// not retain e or buf past the call.
+type Formatter interface {
+	Format(e *Entry, buf *[]byte) error
+}
+
+// formatterBox exists only so Logger.formatter (an atomic.Value) always
+// stores the same concrete type - atomic.Value panics if you Store values
+// of differing concrete types, and swapping between *TextFormatter,
+// *JSONFormatter, etc. via SetFormatter would otherwise trip that.
+type formatterBox struct {
+	f Formatter
+}
+
+// TextFormatter reproduces golog's original layout: "LEVEL name <header>
+// message", honoring the same Ldate/Ltime/Lshortfile/Llongfile flag bits
+// Logger has always supported.
+type TextFormatter struct {
+	flag atomicFlag
+}
+
+// NewTextFormatter creates a TextFormatter with the given flag bits (see
+// Ldate, Ltime, Lmicroseconds, Lshortfile, Llongfile).
+func NewTextFormatter(flag int) *TextFormatter {
+	f := &TextFormatter{}
+	f.SetFlag(flag)
+	return f
+}
+
+func (f *TextFormatter) SetFlag(flag int) {
+	f.flag.Store(flag)
+}
+
+func (f *TextFormatter) Flag() int {
+	return f.flag.Load()
+}
+
+func (f *TextFormatter) Format(e *Entry, buf *[]byte) error {
+	colorLog := e.Color != NoColor
+
+	if colorLog {
+		*buf = append(*buf, logColorPrefix[e.Color]...)
+	}
+
+	*buf = append(*buf, levelString[e.Level]...)
+	*buf = append(*buf, ' ')
+	*buf = append(*buf, e.Name...)
+
+	formatHeader(buf, f.Flag(), e.Time, e.File, e.Line)
+
+	*buf = append(*buf, e.Message...)
+
+	if colorLog {
+		*buf = append(*buf, logColorSuffix...)
+	}
+
+	if len(e.Message) > 0 && e.Message[len(e.Message)-1] != '\n' {
+		*buf = append(*buf, '\n')
+	}
+
+	return nil
+}
+
+// JSONFormatter writes one JSON object per line, suitable for feeding
+// straight into ELK/Loki-style pipelines.
+type JSONFormatter struct{}
+
+type jsonEntry struct {
+	Time    string                 `json:"time"`
+	Level   string                 `json:"level"`
+	Name    string                 `json:"name,omitempty"`
+	File    string                 `json:"file,omitempty"`
+	Line    int                    `json:"line,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+func (JSONFormatter) Format(e *Entry, buf *[]byte) error {
+	je := jsonEntry{
+		Time:    e.Time.Format(time.RFC3339Nano),
+		Level:   levelString[e.Level],
+		Name:    e.Name,
+		File:    e.File,
+		Line:    e.Line,
+		Message: strings.TrimSuffix(e.Message, "\n"),
+		Fields:  e.Fields,
+	}
+
+	b, err := json.Marshal(je)
+	if err != nil {
+		return err
+	}
+
+	*buf = append(*buf, b...)
+	*buf = append(*buf, '\n')
+	return nil
+}
+
+// LogfmtFormatter writes key=value pairs, quoting any value that contains
+// whitespace or an '=' so the line stays parseable.
+type LogfmtFormatter struct{}
+
+func (LogfmtFormatter) Format(e *Entry, buf *[]byte) error {
+	appendLogfmtKV(buf, "time", e.Time.Format(time.RFC3339Nano))
+	appendLogfmtKV(buf, "level", levelString[e.Level])
+
+	if e.Name != "" {
+		appendLogfmtKV(buf, "name", e.Name)
+	}
+	if e.File != "" {
+		appendLogfmtKV(buf, "file", fmt.Sprintf("%s:%d", e.File, e.Line))
+	}
+
+	appendLogfmtKV(buf, "msg", strings.TrimSuffix(e.Message, "\n"))
+
+	for _, k := range sortedFieldKeys(e.Fields) {
+		appendLogfmtKV(buf, k, fmt.Sprint(e.Fields[k]))
+	}
+
+	*buf = append(*buf, '\n')
+	return nil
+}
+
+func appendLogfmtKV(buf *[]byte, key, value string) {
+	if len(*buf) > 0 {
+		*buf = append(*buf, ' ')
+	}
+	*buf = append(*buf, key...)
+	*buf = append(*buf, '=')
+	if strings.ContainsAny(value, " \"=\t\n") {
+		*buf = append(*buf, strconv.Quote(value)...)
+	} else {
+		*buf = append(*buf, value...)
+	}
+}
+
+func sortedFieldKeys(fields map[string]interface{}) []string {
+	if len(fields) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
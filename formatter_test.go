@@ -0,0 +1,63 @@
+package golog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLogfmtFormatterQuotesValuesWithSpaces(t *testing.T) {
+	e := &Entry{
+		Level:   Level_Info,
+		Time:    time.Unix(0, 0).UTC(),
+		Name:    "svc",
+		Message: "hello world",
+		Fields:  map[string]interface{}{"req_id": "abc 123"},
+	}
+
+	var buf []byte
+	if err := (LogfmtFormatter{}).Format(e, &buf); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	out := string(buf)
+	if !strings.Contains(out, `msg=hello world`) {
+		t.Errorf("expected unquoted msg, got %q", out)
+	}
+	if !strings.Contains(out, `req_id="abc 123"`) {
+		t.Errorf("expected quoted field with a space, got %q", out)
+	}
+}
+
+func TestJSONFormatterRoundTrips(t *testing.T) {
+	e := &Entry{
+		Level:   Level_Warn,
+		Time:    time.Unix(0, 0).UTC(),
+		Name:    "svc",
+		Message: "uh oh\n",
+	}
+
+	var buf []byte
+	if err := (JSONFormatter{}).Format(e, &buf); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	if !strings.HasPrefix(string(buf), "{") {
+		t.Fatalf("expected a JSON object, got %q", buf)
+	}
+	if strings.Contains(string(buf), "uh oh\\n") {
+		t.Errorf("expected the trailing newline to be trimmed before encoding, got %q", buf)
+	}
+}
+
+func TestWithFieldsDoesNotMutateParent(t *testing.T) {
+	l := New("parent")
+	child := l.WithField("req_id", "abc").WithFields(map[string]interface{}{"user": "bob"})
+
+	if len(l.Fields()) != 0 {
+		t.Fatalf("expected parent logger to have no fields, got %v", l.Fields())
+	}
+	if child.Fields()["req_id"] != "abc" || child.Fields()["user"] != "bob" {
+		t.Fatalf("expected child to carry both fields, got %v", child.Fields())
+	}
+}
@@ -18,9 +18,35 @@ import (
 	"os"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// maxPooledBufSize bounds how large a buffer we'll return to bufPool. Buffers
+// grown past this (e.g. from one enormous log line) are simply discarded
+// instead of pinning megabytes of memory for the lifetime of the pool.
+const maxPooledBufSize = 64 * 1024
+
+var bufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 256)
+		return &b
+	},
+}
+
+func getBuf() *[]byte {
+	bp := bufPool.Get().(*[]byte)
+	*bp = (*bp)[:0]
+	return bp
+}
+
+func putBuf(bp *[]byte) {
+	if cap(*bp) > maxPooledBufSize {
+		return
+	}
+	bufPool.Put(bp)
+}
+
 // These flags define which text to prefix to each log entry generated by the Logger.
 const (
 	// Bits or'ed together to control what's printed. There is no control over the
@@ -40,17 +66,37 @@ const (
 // output to an io.Writer.  Each logging operation makes a single call to
 // the Writer's Write method.  A Logger can be used simultaneously from
 // multiple goroutines; it guarantees to serialize access to the Writer.
+//
+// The hot fields (flag, level, panicLevel, enableColor, name, colorFile) are
+// all accessed through atomics so that Log can read them, format the entry,
+// and resolve the caller without ever taking a lock. outMu is only held for
+// the final write to out, which is the one operation that must stay
+// serialized to keep lines from interleaving.
 type Logger struct {
-	mu          sync.Mutex // ensures atomic writes; protects the following fields
-	flag        int        // properties
-	buf         []byte     // for accumulating text to write
-	level       Level
-	panicLevel  Level
-	enableColor bool
-	name        string
-	colorFile   *ColorFile
+	flag        atomic.Int64   // properties
+	level       atomic.Value   // Level
+	panicLevel  atomic.Value   // Level
+	enableColor atomic.Bool
+	name        atomic.Value   // string
+	colorFile   atomic.Pointer[ColorFile]
 
-	fileOutput *os.File
+	isDiscard  atomic.Bool // fast path: out is os.DevNull; with no sinks either, Log skips formatting entirely
+	fileOutput atomic.Pointer[os.File]
+
+	formatter atomic.Value // formatterBox
+	fields    atomic.Value // map[string]interface{}, context fields from WithField/WithFields
+
+	sinksMu sync.Mutex   // serializes AddSink/RemoveSink's copy-on-write swap
+	sinks   atomic.Value // []Sink
+
+	flushInterval   atomic.Int64 // nanoseconds; 0 means defaultFlushInterval
+	flushDaemonOnce sync.Once
+
+	sampler            atomic.Value // samplerBox
+	suppressedCount    atomic.Int64
+	lastSuppressReport atomic.Int64 // UnixNano of the last "N suppressed" report
+
+	outMu sync.Mutex // serializes the final write so lines don't interleave
 }
 
 // New creates a new Logger.   The out variable sets the
@@ -59,23 +105,138 @@ type Logger struct {
 // The flag argument defines the logging properties.
 
 func New(name string) *Logger {
-	l := &Logger{flag: LstdFlags, level: Level_Debug, name: name, panicLevel: Level_Fatal}
+	l := &Logger{}
+	l.flag.Store(int64(LstdFlags))
+	l.level.Store(Level_Debug)
+	l.panicLevel.Store(Level_Fatal)
+	l.name.Store(name)
+	l.formatter.Store(formatterBox{f: NewTextFormatter(LstdFlags)})
 
 	add(l)
 
 	return l
 }
 
+// SetFormatter replaces the Logger's Formatter, switching how every
+// subsequent Entry is rendered (text, JSON, logfmt, or a custom
+// implementation).
+func (self *Logger) SetFormatter(f Formatter) {
+	self.formatter.Store(formatterBox{f: f})
+}
+
+func (self *Logger) getFormatter() Formatter {
+	return self.formatter.Load().(formatterBox).f
+}
+
+// formatterWantsCallerInfo reports whether f has its own Lshortfile/
+// Llongfile bits set, independent of the Logger's SetFlag. Log consults
+// this alongside its own flag so that SetFormatter(NewTextFormatter(...))
+// alone - without a matching SetFlag call - is enough to get the caller
+// resolved; without it, a Logger configured purely through SetFormatter
+// would always see an empty Entry.File/Line because Log's runtime.Caller
+// gate only ever looked at its own flag.
+func formatterWantsCallerInfo(f Formatter) bool {
+	fa, ok := f.(interface{ Flag() int })
+	return ok && fa.Flag()&(Lshortfile|Llongfile) != 0
+}
+
+// Fields returns the context fields attached via WithField/WithFields, or
+// nil if none were attached.
+func (self *Logger) Fields() map[string]interface{} {
+	if v := self.fields.Load(); v != nil {
+		return v.(map[string]interface{})
+	}
+	return nil
+}
+
+// clone returns a new Logger that shares this one's configuration (flag,
+// level, output, formatter, sinks, sampler, ...) but has its own zero-value
+// outMu. It backs WithField/WithFields: Logger embeds a sync.Mutex, so a
+// plain struct copy would copy a mutex that might be held - we rebuild the
+// atomics instead.
+//
+// Unlike New, clone deliberately does NOT register nl in the package
+// registry or start a flush daemon for it. WithField/WithFields is meant
+// for building a short-lived, per-request/per-call logger over a shared
+// base, and nl aliases the same []Sink slice self has - so self (which is
+// registered, with its own daemon already running) already keeps those
+// sinks flushed. Registering every clone too would make the registry, and
+// therefore every Flush() call, grow without bound for as long as the
+// program builds fielded loggers.
+func (self *Logger) clone() *Logger {
+	nl := &Logger{}
+	nl.flag.Store(self.flag.Load())
+	nl.level.Store(self.level.Load())
+	nl.panicLevel.Store(self.panicLevel.Load())
+	nl.enableColor.Store(self.enableColor.Load())
+	nl.name.Store(self.Name())
+	if cf := self.colorFile.Load(); cf != nil {
+		nl.colorFile.Store(cf)
+	}
+	nl.isDiscard.Store(self.isDiscard.Load())
+	if fo := self.fileOutput.Load(); fo != nil {
+		nl.fileOutput.Store(fo)
+	}
+	nl.formatter.Store(self.formatter.Load())
+	if f := self.Fields(); f != nil {
+		nl.fields.Store(f)
+	}
+	if sinks := self.getSinks(); len(sinks) > 0 {
+		nl.sinks.Store(sinks)
+		nl.flushInterval.Store(self.flushInterval.Load())
+	}
+	if v := self.sampler.Load(); v != nil {
+		nl.sampler.Store(v)
+	}
+
+	return nl
+}
+
+// WithField returns a shallow clone of the Logger that merges k=v into the
+// fields attached to every subsequent entry, leaving self untouched.
+func (self *Logger) WithField(k string, v interface{}) *Logger {
+	return self.WithFields(map[string]interface{}{k: v})
+}
+
+// WithFields returns a shallow clone of the Logger that merges fields into
+// the fields attached to every subsequent entry, leaving self untouched.
+func (self *Logger) WithFields(fields map[string]interface{}) *Logger {
+	nl := self.clone()
+
+	merged := make(map[string]interface{}, len(self.Fields())+len(fields))
+	for k, v := range self.Fields() {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	nl.fields.Store(merged)
+
+	return nl
+}
+
 func (self *Logger) SetFlag(v int) {
-	self.flag = v
+	self.flag.Store(int64(v))
+	if tf, ok := self.getFormatter().(*TextFormatter); ok {
+		tf.SetFlag(v)
+	}
 }
 
 func (self *Logger) Flag() int {
-	return self.flag
+	return int(self.flag.Load())
 }
 
 func (self *Logger) Name() string {
-	return self.name
+	return self.name.Load().(string)
+}
+
+// SetOutput sets the file the logger writes to, or clears it (falling back
+// to os.Stdout) when out is nil. It also refreshes the isDiscard fast path
+// so that Log can skip formatting entirely when the destination is
+// io.Discard's on-disk equivalent, os.DevNull.
+func (self *Logger) SetOutput(out *os.File) {
+	self.fileOutput.Store(out)
+	self.isDiscard.Store(out != nil && out.Name() == os.DevNull)
 }
 
 // Cheap integer to fixed-width decimal ASCII.  Give a negative width to avoid zero-padding.
@@ -98,11 +259,14 @@ func itoa(buf *[]byte, i int, wid int) {
 	*buf = append(*buf, b[bp:]...)
 }
 
-func (self *Logger) formatHeader(buf *[]byte, t time.Time, file string, line int) {
+// formatHeader takes flag as a plain argument rather than reading it off a
+// Logger so that it can run outside of any lock - the caller loads it once
+// with a single atomic read up front.
+func formatHeader(buf *[]byte, flag int, t time.Time, file string, line int) {
 
 	*buf = append(*buf, ' ')
-	if self.flag&(Ldate|Ltime|Lmicroseconds) != 0 {
-		if self.flag&Ldate != 0 {
+	if flag&(Ldate|Ltime|Lmicroseconds) != 0 {
+		if flag&Ldate != 0 {
 			year, month, day := t.Date()
 			itoa(buf, year, 4)
 			*buf = append(*buf, '/')
@@ -111,22 +275,22 @@ func (self *Logger) formatHeader(buf *[]byte, t time.Time, file string, line int
 			itoa(buf, day, 2)
 			*buf = append(*buf, ' ')
 		}
-		if self.flag&(Ltime|Lmicroseconds) != 0 {
+		if flag&(Ltime|Lmicroseconds) != 0 {
 			hour, min, sec := t.Clock()
 			itoa(buf, hour, 2)
 			*buf = append(*buf, ':')
 			itoa(buf, min, 2)
 			*buf = append(*buf, ':')
 			itoa(buf, sec, 2)
-			if self.flag&Lmicroseconds != 0 {
+			if flag&Lmicroseconds != 0 {
 				*buf = append(*buf, '.')
 				itoa(buf, t.Nanosecond()/1e3, 6)
 			}
 			*buf = append(*buf, ' ')
 		}
 	}
-	if self.flag&(Lshortfile|Llongfile) != 0 {
-		if self.flag&Lshortfile != 0 {
+	if flag&(Lshortfile|Llongfile) != 0 {
+		if flag&Lshortfile != 0 {
 			short := file
 			for i := len(file) - 1; i > 0; i-- {
 				if file[i] == '/' {
@@ -153,51 +317,64 @@ func (self *Logger) Output(calldepth int, prefix string, text string, c Color, o
 	now := time.Now() // get this early.
 	var file string
 	var line int
-	self.mu.Lock()
-	defer self.mu.Unlock()
-	if self.flag&(Lshortfile|Llongfile) != 0 {
-		// release lock while getting caller info - it'text expensive.
-		self.mu.Unlock()
+	flag := self.Flag()
+	if flag&(Lshortfile|Llongfile) != 0 {
 		var ok bool
 		_, file, line, ok = runtime.Caller(calldepth)
 		if !ok {
 			file = "???"
 			line = 0
 		}
-		self.mu.Lock()
 	}
-	self.buf = self.buf[:0]
+
+	bufp := getBuf()
+	buf := *bufp
 
 	colorLog := c != NoColor
 
 	if colorLog {
-		self.buf = append(self.buf, logColorPrefix[c]...)
+		buf = append(buf, logColorPrefix[c]...)
 	}
 
-	self.buf = append(self.buf, prefix...)
-	self.formatHeader(&self.buf, now, file, line)
-	self.buf = append(self.buf, text...)
+	buf = append(buf, prefix...)
+	formatHeader(&buf, flag, now, file, line)
+	buf = append(buf, text...)
 
 	if colorLog {
-		self.buf = append(self.buf, logColorSuffix...)
+		buf = append(buf, logColorSuffix...)
 	}
 
 	if len(text) > 0 && text[len(text)-1] != '\n' {
-		self.buf = append(self.buf, '\n')
+		buf = append(buf, '\n')
 	}
 
-	_, err := out.Write(self.buf)
+	err := self.writeBuf(buf, out)
+
+	*bufp = buf
+	putBuf(bufp)
 
 	return err
 }
 
+// writeBuf performs the one step that must stay serialized: the write to
+// out. Everything that produces buf (caller lookup, formatting, coloring)
+// happens outside of outMu.
+func (self *Logger) writeBuf(buf []byte, out io.Writer) error {
+	self.outMu.Lock()
+	_, err := out.Write(buf)
+	self.outMu.Unlock()
+	return err
+}
+
 func (self *Logger) Log(c Color, level Level, format string, v ...interface{}) {
 
-	if level < self.level {
+	if level < self.Level() {
 		return
 	}
 
-	prefix := fmt.Sprintf("%s %s", levelString[level], self.name)
+	if self.isDiscard.Load() && len(self.getSinks()) == 0 {
+		return
+	}
 
 	var text string
 
@@ -207,12 +384,16 @@ func (self *Logger) Log(c Color, level Level, format string, v ...interface{}) {
 		text = fmt.Sprintf(format, v...)
 	}
 
-	var out io.Writer
+	if sampler := self.getSampler(); sampler != nil && !sampler.Allow(level, text) {
+		self.recordSuppressed()
+		return
+	}
 
-	if self.enableColor {
+	if self.enableColor.Load() {
 
-		if self.colorFile != nil && c == NoColor {
-			c = self.colorFile.ColorFromText(text)
+		colorFile := self.colorFile.Load()
+		if colorFile != nil && c == NoColor {
+			c = colorFile.ColorFromText(text)
 		}
 
 		if level >= Level_Error {
@@ -222,15 +403,60 @@ func (self *Logger) Log(c Color, level Level, format string, v ...interface{}) {
 		c = NoColor
 	}
 
-	if self.fileOutput == nil {
+	formatter := self.getFormatter()
+
+	flag := self.Flag()
+	var file string
+	var line int
+	if flag&(Lshortfile|Llongfile) != 0 || formatterWantsCallerInfo(formatter) {
+		var ok bool
+		_, file, line, ok = runtime.Caller(2)
+		if !ok {
+			file = "???"
+			line = 0
+		}
+	}
+
+	e := Entry{
+		Level:   level,
+		Time:    time.Now(),
+		Name:    self.Name(),
+		File:    file,
+		Line:    line,
+		Message: text,
+		Fields:  self.Fields(),
+		Color:   c,
+	}
+
+	fileOutput := self.fileOutput.Load()
+
+	var out io.Writer
+
+	if fileOutput == nil {
 		out = os.Stdout
 	} else {
-		out = self.fileOutput
+		out = fileOutput
 	}
 
-	self.Output(3, prefix, text, c, out)
+	bufp := getBuf()
+	buf := *bufp
+	if err := formatter.Format(&e, &buf); err == nil {
+		self.writeBuf(buf, out)
 
-	if int(level) >= int(self.panicLevel) {
+		for _, s := range self.getSinks() {
+			s.Write(&e, buf)
+		}
+	}
+	*bufp = buf
+	putBuf(bufp)
+
+	panicLevel := self.panicLevel.Load().(Level)
+	if int(level) >= int(panicLevel) {
+		if level == Level_Fatal {
+			// Buffered/async sinks only push their tail out on their own
+			// schedule; make sure this entry survives the panic unwind.
+			Flush()
+		}
 		panic(text)
 	}
 
@@ -291,13 +517,22 @@ func (self *Logger) Errorln(v ...interface{}) {
 	self.Log(ColorFromLevel(Level_Error), Level_Error, "", v...)
 }
 
+// Fatalf logs at Level_Fatal and then terminates the process. If
+// panicLevel is at its default (Level_Fatal), Log itself flushes and
+// panics before this function gets a chance to return; SetPanicLevelByString
+// can raise panicLevel so that Fatal logs here instead, in which case
+// Flush/os.Exit below are what actually end the process.
 func (self *Logger) Fatalf(format string, v ...interface{}) {
 
 	self.Log(ColorFromLevel(Level_Fatal), Level_Fatal, format, v...)
+	Flush()
+	os.Exit(1)
 }
 
 func (self *Logger) Fatalln(v ...interface{}) {
 	self.Log(ColorFromLevel(Level_Fatal), Level_Fatal, "", v...)
+	Flush()
+	os.Exit(1)
 }
 
 func (self *Logger) SetLevelByString(level string) {
@@ -307,22 +542,22 @@ func (self *Logger) SetLevelByString(level string) {
 }
 
 func (self *Logger) SetLevel(lv Level) {
-	self.level = lv
+	self.level.Store(lv)
 }
 
 func (self *Logger) Level() Level {
-	return self.level
+	return self.level.Load().(Level)
 }
 
 func (self *Logger) SetPanicLevelByString(level string) {
-	self.panicLevel = str2loglevel(level)
+	self.panicLevel.Store(str2loglevel(level))
 
 }
 
 // 注意, 加色只能在Gogland的main方式启用, Test方式无法加色
 func (self *Logger) SetColorFile(file *ColorFile) {
-	self.colorFile = file
+	self.colorFile.Store(file)
 }
 func (self *Logger) IsDebugEnabled() bool {
-	return self.level == Level_Debug
+	return self.Level() == Level_Debug
 }
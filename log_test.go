@@ -0,0 +1,105 @@
+package golog
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestLshortfileReportsCallSite guards against Log resolving the wrong
+// runtime.Caller depth: Infof calls Log directly (no intermediate Output
+// indirection), so the frame two above runtime.Caller's own call inside Log
+// must land on the line below, not some frame inside the standard library.
+func TestLshortfileReportsCallSite(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("caller-test")
+	l.SetFlag(Lshortfile)
+	l.SetFormatter(NewTextFormatter(Lshortfile))
+	l.AddSink(NewWriterSink(&buf))
+
+	_, _, here, _ := runtime.Caller(0)
+	l.Infof("hello")
+
+	got := buf.String()
+	want := fmt.Sprintf("log_test.go:%d", here+1)
+	if !strings.Contains(got, want) {
+		t.Fatalf("expected output to contain %q, got %q", want, got)
+	}
+}
+
+// TestLshortfileFromFormatterAloneReportsCallSite guards against Log's
+// runtime.Caller gate looking only at the Logger's own flag (set via
+// SetFlag) and ignoring the active Formatter's. SetFormatter(
+// NewTextFormatter(Lshortfile)) without a matching SetFlag call is exactly
+// how the two setters are documented to be used independently, and must
+// resolve the caller just as well as SetFlag does.
+func TestLshortfileFromFormatterAloneReportsCallSite(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("formatter-caller-test")
+	l.SetFormatter(NewTextFormatter(Lshortfile))
+	l.AddSink(NewWriterSink(&buf))
+
+	_, _, here, _ := runtime.Caller(0)
+	l.Infof("hello")
+
+	got := buf.String()
+	want := fmt.Sprintf("log_test.go:%d", here+1)
+	if !strings.Contains(got, want) {
+		t.Fatalf("expected output to contain %q, got %q", want, got)
+	}
+}
+
+// BenchmarkConcurrent drives many goroutines through Log at once. With the
+// old design every call serialized on Logger.mu for the full format+write;
+// here only the final out.Write is serialized, so throughput should scale
+// with GOMAXPROCS instead of flatlining.
+func BenchmarkConcurrent(b *testing.B) {
+	f, err := os.OpenFile(b.TempDir()+"/bench.log", os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer f.Close()
+
+	l := New("bench")
+	l.SetFlag(LstdFlags | Lshortfile)
+	l.SetOutput(f)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var wg sync.WaitGroup
+	b.RunParallel(func(pb *testing.PB) {
+		wg.Add(1)
+		defer wg.Done()
+		for pb.Next() {
+			l.Infof("hello %d, %s", 42, "world")
+		}
+	})
+	wg.Wait()
+}
+
+// BenchmarkConcurrentDiscard exercises the isDiscard fast path, which should
+// skip formatting entirely and be close to free.
+func BenchmarkConcurrentDiscard(b *testing.B) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer devNull.Close()
+
+	l := New("bench-discard")
+	l.SetOutput(devNull)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			l.Infof("hello %d, %s", 42, "world")
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package golog
+
+import "sync"
+
+// registry tracks every Logger created via New, so that package-level
+// operations like Flush can reach all of them without callers having to
+// keep their own list around.
+var (
+	registryMu sync.Mutex
+	registry   []*Logger
+)
+
+func add(l *Logger) {
+	registryMu.Lock()
+	registry = append(registry, l)
+	registryMu.Unlock()
+}
+
+// Flush drains every sink on every registered Logger. Buffered and
+// asynchronous sinks (RotatingFileSink, AsyncSink) otherwise only push
+// their tail out on their own schedule, which can lose messages if the
+// process dies in between - call Flush before a clean shutdown, or see
+// EnableSignalFlush for the crash/signal case.
+func Flush() {
+	registryMu.Lock()
+	loggers := make([]*Logger, len(registry))
+	copy(loggers, registry)
+	registryMu.Unlock()
+
+	for _, l := range loggers {
+		l.flushSinks()
+	}
+}
@@ -0,0 +1,261 @@
+package golog
+
+import (
+	"container/list"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Sampler decides whether a given log call should actually be emitted. It's
+// consulted in Log after the level check and after the message text is
+// rendered, but before the Entry is built and run through the Formatter, so
+// a Sampler that drops most calls keeps the hot path cheap.
+type Sampler interface {
+	Allow(level Level, msg string) bool
+}
+
+// samplerCallerSkip walks back through callerPC -> Allow -> Log ->
+// Debugf/Infof/... to the line that actually called one of those. Two frames
+// deeper than Log's own runtime.Caller depth, since callerPC and Allow are
+// extra function calls Log doesn't have.
+const samplerCallerSkip = 4
+
+func callerPC(skip int) uintptr {
+	pc, _, _, ok := runtime.Caller(skip)
+	if !ok {
+		return 0
+	}
+	return pc
+}
+
+// lruCache is a fixed-capacity, least-recently-used map used to bound the
+// memory the samplers below spend remembering per-call-site state - without
+// a cap, a program with call sites scattered across millions of distinct
+// PCs (or messages) could otherwise grow this without limit.
+type lruCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List
+	items    map[interface{}]*list.Element
+}
+
+type lruEntry struct {
+	key   interface{}
+	value interface{}
+}
+
+func newLRUCache(capacity int) *lruCache {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &lruCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[interface{}]*list.Element),
+	}
+}
+
+// upsert atomically fetches the current value for key (nil, false if
+// absent), runs fn to compute the replacement, stores it, and returns it -
+// all under one lock, so concurrent callers updating the same key's
+// windowed counters never race.
+func (c *lruCache) upsert(key interface{}, fn func(old interface{}, found bool) interface{}) interface{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		c.ll.MoveToFront(el)
+		entry := el.Value.(*lruEntry)
+		entry.value = fn(entry.value, true)
+		return entry.value
+	}
+
+	value := fn(nil, false)
+	el := c.ll.PushFront(&lruEntry{key: key, value: value})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*lruEntry).key)
+		}
+	}
+
+	return value
+}
+
+// tokenBucket is a classic token-bucket rate limiter: tokens refill at rate
+// per second up to burst, and each Allow spends one.
+type tokenBucket struct {
+	mu     sync.Mutex
+	tokens float64
+	rate   float64
+	burst  float64
+	last   time.Time
+}
+
+func newTokenBucket(rate, burst float64) *tokenBucket {
+	return &tokenBucket{tokens: burst, rate: rate, burst: burst, last: time.Now()}
+}
+
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// TokenBucketSampler rate-limits to rate events/sec with the given burst,
+// tracked independently per Level so a flood of Debug calls can't starve
+// Error logging or vice versa.
+type TokenBucketSampler struct {
+	rate  float64
+	burst float64
+
+	mu      sync.Mutex
+	buckets map[Level]*tokenBucket
+}
+
+func NewTokenBucketSampler(rate, burst float64) *TokenBucketSampler {
+	return &TokenBucketSampler{rate: rate, burst: burst, buckets: make(map[Level]*tokenBucket)}
+}
+
+func (s *TokenBucketSampler) Allow(level Level, msg string) bool {
+	s.mu.Lock()
+	b, ok := s.buckets[level]
+	if !ok {
+		b = newTokenBucket(s.rate, s.burst)
+		s.buckets[level] = b
+	}
+	s.mu.Unlock()
+
+	return b.allow()
+}
+
+type everyNKey struct {
+	pc  uintptr
+	msg string
+}
+
+// EveryNSampler logs the first occurrence of a message at a given call site
+// and then only 1 of every n repeats after that, identified by call site PC
+// combined with the rendered message (Allow only ever sees the rendered
+// text, so that's the closest proxy to "same format string" available here).
+type EveryNSampler struct {
+	n     int64
+	cache *lruCache
+}
+
+func NewEveryNSampler(n int, cacheSize int) *EveryNSampler {
+	return &EveryNSampler{n: int64(n), cache: newLRUCache(cacheSize)}
+}
+
+func (s *EveryNSampler) Allow(level Level, msg string) bool {
+	if s.n <= 1 {
+		return true
+	}
+
+	key := everyNKey{pc: callerPC(samplerCallerSkip), msg: msg}
+	count := s.cache.upsert(key, func(old interface{}, found bool) interface{} {
+		if !found {
+			return int64(1)
+		}
+		return old.(int64) + 1
+	}).(int64)
+
+	return count%s.n == 1
+}
+
+type tailState struct {
+	windowStart time.Time
+	count       int64
+}
+
+// TailSampler logs the first `first` occurrences of a message per one-second
+// window, then 1 of every `thereafter` after that - the same shape zerolog
+// and zap use for their built-in sampling.
+type TailSampler struct {
+	first      int64
+	thereafter int64
+	cache      *lruCache
+}
+
+func NewTailSampler(first, thereafter int, cacheSize int) *TailSampler {
+	return &TailSampler{first: int64(first), thereafter: int64(thereafter), cache: newLRUCache(cacheSize)}
+}
+
+func (s *TailSampler) Allow(level Level, msg string) bool {
+	key := everyNKey{pc: callerPC(samplerCallerSkip), msg: msg}
+	now := time.Now()
+
+	count := s.cache.upsert(key, func(old interface{}, found bool) interface{} {
+		st, ok := old.(*tailState)
+		if !found || !ok || now.Sub(st.windowStart) >= time.Second {
+			return &tailState{windowStart: now, count: 1}
+		}
+		st.count++
+		return st
+	}).(*tailState).count
+
+	if count <= s.first {
+		return true
+	}
+	if s.thereafter <= 0 {
+		return false
+	}
+	return (count-s.first)%s.thereafter == 0
+}
+
+// SetSampler installs a Sampler that Log consults for every call after the
+// level check. A nil sampler (the default) allows everything through.
+func (self *Logger) SetSampler(s Sampler) {
+	self.sampler.Store(samplerBox{s: s})
+}
+
+type samplerBox struct {
+	s Sampler
+}
+
+func (self *Logger) getSampler() Sampler {
+	if v := self.sampler.Load(); v != nil {
+		return v.(samplerBox).s
+	}
+	return nil
+}
+
+// recordSuppressed tracks one more dropped entry and, once enough time has
+// passed since the last report, emits a synthetic
+// "... N similar messages suppressed" line so the drops aren't silent.
+func (self *Logger) recordSuppressed() {
+	self.suppressedCount.Add(1)
+
+	now := time.Now().UnixNano()
+	last := self.lastSuppressReport.Load()
+	if now-last < int64(suppressedReportInterval) {
+		return
+	}
+	if !self.lastSuppressReport.CompareAndSwap(last, now) {
+		return
+	}
+
+	dropped := self.suppressedCount.Swap(0)
+	if dropped == 0 {
+		return
+	}
+	self.Log(NoColor, Level_Warn, "... %d similar messages suppressed", dropped)
+}
+
+const suppressedReportInterval = 5 * time.Second
@@ -0,0 +1,81 @@
+package golog
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestEveryNSamplerAllowsFirstThenEveryNth(t *testing.T) {
+	s := NewEveryNSampler(3, 16)
+
+	var got []bool
+	for i := 0; i < 7; i++ {
+		got = append(got, s.Allow(Level_Info, "repeated"))
+	}
+
+	want := []bool{true, false, false, true, false, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: got %v, want %v (full: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestTailSamplerAllowsFirstMThenThereafter(t *testing.T) {
+	s := NewTailSampler(2, 5, 16)
+
+	var got []bool
+	for i := 0; i < 12; i++ {
+		got = append(got, s.Allow(Level_Info, "repeated"))
+	}
+
+	// first=2 pass, then every 5th after that: indices (0-based) 0,1 pass,
+	// then 6,11 (the 7th and 12th calls: 2 + 5, 2 + 10) pass.
+	want := []bool{true, true, false, false, false, false, true, false, false, false, false, true}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("call %d: got %v, want %v (full: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestTokenBucketSamplerEnforcesBurst(t *testing.T) {
+	s := NewTokenBucketSampler(0, 3)
+
+	for i := 0; i < 3; i++ {
+		if !s.Allow(Level_Info, "x") {
+			t.Fatalf("call %d: expected burst capacity to allow it", i)
+		}
+	}
+	if s.Allow(Level_Info, "x") {
+		t.Fatal("expected the 4th call to exceed burst capacity with rate 0")
+	}
+}
+
+func sampledCallSiteA(l *Logger) { l.Infof("repeated message") }
+func sampledCallSiteB(l *Logger) { l.Infof("repeated message") }
+
+// TestEveryNSamplerTracksDistinctCallSitesThroughLogger drives the sampler
+// through Logger.Infof - rather than calling Allow directly, like the tests
+// above - so that the extra stack frames Allow's callerPC walks through
+// (Allow -> Log -> Infof -> caller) are actually exercised. With the wrong
+// samplerCallerSkip, both call sites resolve to the same PC inside Infof and
+// get throttled as if they were one site, even though they render the same
+// message independently.
+func TestEveryNSamplerTracksDistinctCallSitesThroughLogger(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("sampler-callsite-test")
+	l.AddSink(NewWriterSink(&buf))
+	l.SetSampler(NewEveryNSampler(2, 16))
+
+	for i := 0; i < 4; i++ {
+		sampledCallSiteA(l)
+		sampledCallSiteB(l)
+	}
+
+	got := buf.String()
+	if n := strings.Count(got, "repeated message"); n != 4 {
+		t.Fatalf("expected each of the two independent call sites to pass 2 of its 4 calls (4 total), got %d (output: %q)", n, got)
+	}
+}
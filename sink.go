@@ -0,0 +1,390 @@
+package golog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink is a destination for formatted log entries. Logger can fan a single
+// Entry out to any number of sinks (see AddSink), each of which decides for
+// itself how and when the bytes actually land.
+type Sink interface {
+	// Write receives both the structured Entry and its already-formatted
+	// bytes, so a sink that's happy with the Logger's default formatting
+	// can skip reformatting entirely.
+	Write(e *Entry, formatted []byte) error
+	Flush() error
+	Close() error
+}
+
+// WriterSink adapts any io.Writer into a Sink.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+func (s *WriterSink) Write(e *Entry, formatted []byte) error {
+	s.mu.Lock()
+	_, err := s.w.Write(formatted)
+	s.mu.Unlock()
+	return err
+}
+
+func (s *WriterSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f, ok := s.w.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	if f, ok := s.w.(interface{ Sync() error }); ok {
+		return f.Sync()
+	}
+	return nil
+}
+
+func (s *WriterSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// leveledSink wraps a Sink with a minimum Level and, optionally, its own
+// Formatter - the composition Logger.AddSink relies on to give each sink an
+// independent level threshold and rendering without widening the Sink
+// interface itself. Build one with NewLeveledSink.
+type leveledSink struct {
+	Sink
+	level     Level
+	formatter Formatter
+}
+
+// NewLeveledSink wraps sink so it only receives entries at or above level,
+// reformatting with formatter first if one is given (nil keeps whatever the
+// Logger already formatted).
+func NewLeveledSink(sink Sink, level Level, formatter Formatter) Sink {
+	return &leveledSink{Sink: sink, level: level, formatter: formatter}
+}
+
+func (s *leveledSink) Write(e *Entry, formatted []byte) error {
+	if e.Level < s.level {
+		return nil
+	}
+	if s.formatter != nil {
+		var buf []byte
+		if err := s.formatter.Format(e, &buf); err != nil {
+			return err
+		}
+		formatted = buf
+	}
+	return s.Sink.Write(e, formatted)
+}
+
+// RotatingFileSink writes to a file, rotating it once it exceeds maxSize
+// bytes or maxAge since it was opened, whichever comes first (zero disables
+// that trigger). Rotated files are renamed with a timestamp suffix,
+// optionally gzipped, and pruned down to maxBackups.
+type RotatingFileSink struct {
+	path        string
+	maxSize     int64
+	maxAge      time.Duration
+	maxBackups  int
+	gzipBackups bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func NewRotatingFileSink(path string, maxSize int64, maxAge time.Duration, maxBackups int, gzipBackups bool) (*RotatingFileSink, error) {
+	s := &RotatingFileSink{
+		path:        path,
+		maxSize:     maxSize,
+		maxAge:      maxAge,
+		maxBackups:  maxBackups,
+		gzipBackups: gzipBackups,
+	}
+	if err := s.openLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *RotatingFileSink) openLocked() error {
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.file = f
+	s.size = info.Size()
+	s.openedAt = time.Now()
+	return nil
+}
+
+func (s *RotatingFileSink) Write(e *Entry, formatted []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.needsRotateLocked() {
+		if err := s.rotateLocked(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(formatted)
+	s.size += int64(n)
+	return err
+}
+
+func (s *RotatingFileSink) needsRotateLocked() bool {
+	if s.maxSize > 0 && s.size >= s.maxSize {
+		return true
+	}
+	if s.maxAge > 0 && time.Since(s.openedAt) >= s.maxAge {
+		return true
+	}
+	return false
+}
+
+func (s *RotatingFileSink) rotateLocked() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+
+	backup := fmt.Sprintf("%s.%s", s.path, time.Now().Format("20060102T150405.000000000"))
+	if err := os.Rename(s.path, backup); err != nil {
+		return err
+	}
+
+	if s.gzipBackups {
+		if err := gzipFile(backup); err == nil {
+			os.Remove(backup)
+			backup += ".gz"
+		}
+	}
+
+	if err := s.pruneBackupsLocked(); err != nil {
+		return err
+	}
+
+	return s.openLocked()
+}
+
+func (s *RotatingFileSink) pruneBackupsLocked() error {
+	if s.maxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(s.path + ".*")
+	if err != nil {
+		return err
+	}
+	if len(matches) <= s.maxBackups {
+		return nil
+	}
+
+	sort.Strings(matches) // timestamp suffix sorts lexically == chronologically
+	for _, old := range matches[:len(matches)-s.maxBackups] {
+		os.Remove(old)
+	}
+	return nil
+}
+
+func gzipFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(path + ".gz")
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	gw := gzip.NewWriter(out)
+	if _, err := io.Copy(gw, in); err != nil {
+		gw.Close()
+		return err
+	}
+	return gw.Close()
+}
+
+func (s *RotatingFileSink) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Sync()
+}
+
+func (s *RotatingFileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// AsyncDropPolicy controls what AsyncSink does when its buffer channel is
+// full.
+type AsyncDropPolicy int
+
+const (
+	// AsyncBlock makes Write block until the background goroutine catches
+	// up, same as an unbuffered sink.
+	AsyncBlock AsyncDropPolicy = iota
+	// AsyncDrop makes Write return immediately, discarding the entry and
+	// counting it in Dropped().
+	AsyncDrop
+)
+
+type asyncMsg struct {
+	e         *Entry
+	formatted []byte
+	flushed   chan struct{} // set only on a Flush marker; closed once it is reached
+}
+
+// AsyncSink wraps another Sink with a bounded channel and a background
+// goroutine, so a slow underlying sink (disk, network) never blocks the
+// caller of Log - unless policy is AsyncBlock and the buffer fills.
+type AsyncSink struct {
+	sink   Sink
+	ch     chan asyncMsg
+	policy AsyncDropPolicy
+
+	dropped atomic.Int64
+	done    chan struct{}
+}
+
+func NewAsyncSink(sink Sink, bufSize int, policy AsyncDropPolicy) *AsyncSink {
+	s := &AsyncSink{
+		sink:   sink,
+		ch:     make(chan asyncMsg, bufSize),
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *AsyncSink) loop() {
+	defer close(s.done)
+	for msg := range s.ch {
+		if msg.flushed != nil {
+			close(msg.flushed)
+			continue
+		}
+		s.sink.Write(msg.e, msg.formatted)
+	}
+}
+
+func (s *AsyncSink) Write(e *Entry, formatted []byte) error {
+	// formatted is backed by a pooled buffer the caller reclaims right
+	// after this call returns, so we must copy before handing it to the
+	// goroutine.
+	cp := make([]byte, len(formatted))
+	copy(cp, formatted)
+	msg := asyncMsg{e: e, formatted: cp}
+
+	if s.policy == AsyncDrop {
+		select {
+		case s.ch <- msg:
+		default:
+			s.dropped.Add(1)
+		}
+		return nil
+	}
+
+	s.ch <- msg
+	return nil
+}
+
+// Dropped reports how many entries AsyncDrop has discarded because the
+// buffer was full.
+func (s *AsyncSink) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Flush waits until every entry queued before the call has been written to
+// the wrapped sink, then flushes that sink.
+func (s *AsyncSink) Flush() error {
+	flushed := make(chan struct{})
+	s.ch <- asyncMsg{flushed: flushed}
+	<-flushed
+	return s.sink.Flush()
+}
+
+func (s *AsyncSink) Close() error {
+	close(s.ch)
+	<-s.done
+	return s.sink.Close()
+}
+
+// AddSink registers sink to receive every entry this Logger logs, in
+// addition to its primary output. Sinks are invoked after the Logger's own
+// formatting pass, so a sink that doesn't need its own Formatter costs
+// nothing extra per entry.
+func (self *Logger) AddSink(sink Sink) {
+	self.sinksMu.Lock()
+	defer self.sinksMu.Unlock()
+
+	existing := self.getSinks()
+	next := make([]Sink, len(existing), len(existing)+1)
+	copy(next, existing)
+	next = append(next, sink)
+	self.sinks.Store(next)
+
+	self.ensureFlushDaemon()
+}
+
+// RemoveSink unregisters sink. It is a no-op if sink was never added.
+func (self *Logger) RemoveSink(sink Sink) {
+	self.sinksMu.Lock()
+	defer self.sinksMu.Unlock()
+
+	existing := self.getSinks()
+	next := make([]Sink, 0, len(existing))
+	for _, s := range existing {
+		if s != sink {
+			next = append(next, s)
+		}
+	}
+	self.sinks.Store(next)
+}
+
+func (self *Logger) getSinks() []Sink {
+	if v := self.sinks.Load(); v != nil {
+		return v.([]Sink)
+	}
+	return nil
+}
+
+// flushSinks drains every sink registered on this Logger. Errors from
+// individual sinks are collected but don't stop the remaining sinks from
+// being flushed.
+func (self *Logger) flushSinks() error {
+	var firstErr error
+	for _, s := range self.getSinks() {
+		if err := s.Flush(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,79 @@
+package golog
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestDiscardOutputStillReachesSinks guards against the isDiscard fast path
+// short-circuiting Log before the sink fan-out loop runs: muting the
+// primary output (a reasonable way to say "stdout only, ship everything to
+// the file/async sink instead") must not silently mute registered sinks too.
+func TestDiscardOutputStillReachesSinks(t *testing.T) {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close()
+
+	var buf bytes.Buffer
+	l := New("discard-sink-test")
+	l.SetOutput(devNull)
+	l.AddSink(NewWriterSink(&buf))
+
+	l.Infof("hello")
+
+	if buf.Len() == 0 {
+		t.Fatal("expected the sink to receive the entry even though the primary output discards")
+	}
+}
+
+func TestLeveledSinkFiltersBelowThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewLeveledSink(NewWriterSink(&buf), Level_Warn, nil)
+
+	sink.Write(&Entry{Level: Level_Info}, []byte("info line\n"))
+	if buf.Len() != 0 {
+		t.Fatalf("expected Info entry to be filtered out, got %q", buf.String())
+	}
+
+	sink.Write(&Entry{Level: Level_Warn}, []byte("warn line\n"))
+	if buf.String() != "warn line\n" {
+		t.Fatalf("expected Warn entry through, got %q", buf.String())
+	}
+}
+
+func TestAsyncSinkDropsWhenFull(t *testing.T) {
+	block := make(chan struct{})
+	underlying := &blockingSink{block: block}
+	async := NewAsyncSink(underlying, 1, AsyncDrop)
+	defer func() {
+		close(block)
+		async.Close()
+	}()
+
+	// The first write is picked up immediately by the background goroutine
+	// and blocks there; the second fills the size-1 buffer; the third has
+	// nowhere to go and should be dropped.
+	async.Write(&Entry{}, []byte("a"))
+	async.Write(&Entry{}, []byte("b"))
+	async.Write(&Entry{}, []byte("c"))
+
+	time.Sleep(10 * time.Millisecond)
+	if async.Dropped() == 0 {
+		t.Fatalf("expected at least one dropped entry, got 0")
+	}
+}
+
+type blockingSink struct {
+	block chan struct{}
+}
+
+func (s *blockingSink) Write(e *Entry, formatted []byte) error {
+	<-s.block
+	return nil
+}
+func (s *blockingSink) Flush() error { return nil }
+func (s *blockingSink) Close() error { return nil }
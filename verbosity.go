@@ -0,0 +1,183 @@
+package golog
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// verbosity is the global V-level threshold, analogous to glog's -v flag.
+// Logger.V(n) guards on this unless a more specific vmodule pattern matches
+// the calling file.
+var verbosity atomic.Int32
+
+// SetVerbosity sets the global verbosity threshold used by Logger.V.
+func SetVerbosity(level int32) {
+	verbosity.Store(level)
+}
+
+// VFlag implements flag.Value so verbosity can be wired up as a standard
+// -v=N flag, e.g. flag.Var(golog.VFlag{}, "v", "log verbosity level").
+type VFlag struct{}
+
+func (VFlag) String() string {
+	return strconv.FormatInt(int64(verbosity.Load()), 10)
+}
+
+func (VFlag) Set(s string) error {
+	n, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return err
+	}
+	SetVerbosity(int32(n))
+	return nil
+}
+
+// vmoduleRule is one "pattern=level" pair parsed out of a SetVModule spec.
+type vmoduleRule struct {
+	pattern string
+	level   int32
+}
+
+var (
+	vmoduleMu    sync.Mutex
+	vmoduleRules []vmoduleRule
+
+	// vmoduleCache remembers the V-level decision for a given call site (the
+	// PC of the caller of V) so that repeated calls from the same line don't
+	// re-run matchGlob against every rule. Invalidated wholesale whenever
+	// SetVModule is called.
+	vmoduleCache sync.Map // map[uintptr]int32
+)
+
+// SetVModule parses a glog-style vmodule spec, a comma-separated list of
+// "pattern=level" pairs such as "gopher*=3,rpc_client=2", matched against
+// the bare basename (directory stripped, ".go" suffix stripped) of the file
+// containing the call site. It overrides the global verbosity for files
+// whose basename matches pattern. Unlike glog's own vmodule, a pattern
+// containing '/' can never match anything here, since the name it's
+// compared against never contains one either - don't write path-qualified
+// patterns like "rpc/*", they'll silently match nothing.
+func SetVModule(spec string) {
+	var rules []vmoduleRule
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		lvl, err := strconv.ParseInt(strings.TrimSpace(kv[1]), 10, 32)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, vmoduleRule{pattern: strings.TrimSpace(kv[0]), level: int32(lvl)})
+	}
+
+	vmoduleMu.Lock()
+	vmoduleRules = rules
+	vmoduleMu.Unlock()
+
+	vmoduleCache.Range(func(k, _ interface{}) bool {
+		vmoduleCache.Delete(k)
+		return true
+	})
+}
+
+// vmoduleLevelFor returns the vmodule-specific verbosity level for the file
+// at pc, and whether any pattern matched it at all.
+func vmoduleLevelFor(pc uintptr) (int32, bool) {
+	if cached, ok := vmoduleCache.Load(pc); ok {
+		level := cached.(int32)
+		return level, level >= 0
+	}
+
+	frames := runtime.CallersFrames([]uintptr{pc})
+	frame, _ := frames.Next()
+	file := frame.File
+	if i := strings.LastIndexByte(file, '/'); i >= 0 {
+		file = file[i+1:]
+	}
+	file = strings.TrimSuffix(file, ".go")
+
+	vmoduleMu.Lock()
+	rules := vmoduleRules
+	vmoduleMu.Unlock()
+
+	for _, r := range rules {
+		if ok, _ := matchGlob(r.pattern, file); ok {
+			vmoduleCache.Store(pc, r.level)
+			return r.level, true
+		}
+	}
+
+	// -1 is the cached "no rule matched" sentinel.
+	vmoduleCache.Store(pc, int32(-1))
+	return 0, false
+}
+
+// matchGlob supports the single '*' wildcard glob forms glog's vmodule uses
+// (e.g. "gopher*", "*_test"); it is not a general glob matcher. vmoduleLevelFor
+// only ever calls it with a bare basename, never a path, so a pattern
+// containing '/' is valid syntax but can never match anything in practice.
+func matchGlob(pattern, name string) (bool, error) {
+	if !strings.Contains(pattern, "*") {
+		return pattern == name, nil
+	}
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	return strings.HasPrefix(name, prefix) && strings.HasSuffix(name, suffix), nil
+}
+
+// Verbose is returned by Logger.V. Its methods are no-ops unless the guard
+// that produced it was satisfied, letting callers write
+// l.V(2).Infof("...") without an explicit "if enabled" check at each site.
+type Verbose struct {
+	enabled bool
+	logger  *Logger
+}
+
+// V reports whether verbosity at the given level is enabled for the
+// caller's file, checking the per-file vmodule overrides before falling
+// back to the global verbosity threshold.
+func (self *Logger) V(level int32) Verbose {
+	pc, _, _, ok := runtime.Caller(1)
+	if ok {
+		if vlevel, matched := vmoduleLevelFor(pc); matched {
+			return Verbose{enabled: level <= vlevel, logger: self}
+		}
+	}
+	return Verbose{enabled: level <= verbosity.Load(), logger: self}
+}
+
+func (v Verbose) Infof(format string, args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+	v.logger.Infof(format, args...)
+}
+
+func (v Verbose) Infoln(args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+	v.logger.Infoln(args...)
+}
+
+func (v Verbose) Debugf(format string, args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+	v.logger.Debugf(format, args...)
+}
+
+func (v Verbose) Debugln(args ...interface{}) {
+	if !v.enabled {
+		return
+	}
+	v.logger.Debugln(args...)
+}
@@ -0,0 +1,39 @@
+package golog
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"gopher*", "gopher_test", true},
+		{"gopher*", "other", false},
+		{"rpc/*", "client", false}, // path-qualified patterns never match a bare basename
+		{"server", "server", true},
+		{"server", "serverx", false},
+	}
+
+	for _, c := range cases {
+		got, err := matchGlob(c.pattern, c.name)
+		if err != nil {
+			t.Fatalf("matchGlob(%q, %q) error: %v", c.pattern, c.name, err)
+		}
+		if got != c.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", c.pattern, c.name, got, c.want)
+		}
+	}
+}
+
+func TestSetVModuleInvalidatesCache(t *testing.T) {
+	SetVModule("gopher*=3")
+
+	pc := uintptr(0x1234)
+	vmoduleCache.Store(pc, int32(7))
+
+	SetVModule("gopher*=5")
+
+	if _, ok := vmoduleCache.Load(pc); ok {
+		t.Fatal("expected SetVModule to clear the vmodule cache")
+	}
+}